@@ -0,0 +1,103 @@
+// Package client provides a client for the flaki/service HTTP service. Instead of making
+// one request per ID, it requests IDs in batches from /nextid/batch and hands them out from
+// a local buffer, which amortizes the cost of the round-trip for high-throughput callers.
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+// defaultBatchSize is the number of IDs requested per round-trip when Config.BatchSize is
+// not set.
+const defaultBatchSize = 128
+
+// Config configures a Client.
+type Config struct {
+	// BatchSize is the number of IDs requested per round-trip. Defaults to 128.
+	BatchSize int
+
+	// HTTPClient is used to make requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Client is a flaki client that prefetches IDs in batches from a flaki/service instance and
+// hands them out one at a time. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	batchSize  int
+
+	mutex sync.Mutex
+	buf   []uint64
+}
+
+// New returns a Client that fetches IDs from the flaki/service HTTP server at baseURL
+// (e.g. "http://localhost:8080").
+func New(baseURL string, config Config) *Client {
+	var batchSize = config.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	var httpClient = config.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		baseURL:    baseURL,
+		httpClient: httpClient,
+		batchSize:  batchSize,
+	}
+}
+
+// NextID returns the next ID, fetching a new batch from the server if the local buffer is
+// empty.
+func (c *Client) NextID() (uint64, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(c.buf) == 0 {
+		var batch, err = c.fetchBatch()
+		if err != nil {
+			return 0, err
+		}
+		c.buf = batch
+	}
+
+	var id = c.buf[0]
+	c.buf = c.buf[1:]
+	return id, nil
+}
+
+// fetchBatch requests a fresh batch of c.batchSize IDs from the server's /nextid/batch endpoint.
+func (c *Client) fetchBatch() ([]uint64, error) {
+	var endpoint = c.baseURL + "/nextid/batch?n=" + url.QueryEscape(strconv.Itoa(c.batchSize))
+
+	var resp, err = c.httpClient.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch id batch: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not fetch id batch: server returned %s", resp.Status)
+	}
+
+	var batch struct {
+		IDs []uint64 `json:"ids"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&batch); err != nil {
+		return nil, fmt.Errorf("could not decode id batch: %s", err)
+	}
+	if len(batch.IDs) == 0 {
+		return nil, fmt.Errorf("server returned no ids")
+	}
+
+	return batch.IDs, nil
+}
@@ -14,19 +14,26 @@ import (
 	"time"
 )
 
+// These constants describe DefaultLayout, the bit layout Flaki has always used. Since
+// WithLayout was introduced, the actual shifts and masks used by a given Flaki instance are
+// computed from its Layout at construction time; these constants remain for callers that
+// parsed IDs produced under the default layout before per-instance layouts existed.
 const (
-	componentIDBits  = 5
-	nodeIDNodeIDBits = 2
-	sequenceBits     = 15
-	timestampBits    = 64 - componentIDBits - nodeIDNodeIDBits - sequenceBits
-
-	maxComponentID = (1 << componentIDBits) - 1
-	maxNodeID      = (1 << nodeIDNodeIDBits) - 1
-	sequenceMask   = (1 << sequenceBits) - 1
-
-	componentIDShift   = sequenceBits
-	nodeIDNodeIDShift  = sequenceBits + componentIDBits
-	timestampLeftShift = sequenceBits + componentIDBits + nodeIDNodeIDBits
+	// ComponentIDBits is the number of bits used to encode the component ID in DefaultLayout.
+	ComponentIDBits = 5
+	// NodeIDBits is the number of bits used to encode the node ID in DefaultLayout.
+	NodeIDBits = 2
+	// SequenceBits is the number of bits used to encode the per-millisecond sequence number in DefaultLayout.
+	SequenceBits = 15
+	// TimestampBits is the number of bits used to encode the timestamp in DefaultLayout.
+	TimestampBits = 64 - ComponentIDBits - NodeIDBits - SequenceBits
+
+	// ComponentIDShift is the left shift applied to the component ID under DefaultLayout.
+	ComponentIDShift = SequenceBits
+	// NodeIDShift is the left shift applied to the node ID under DefaultLayout.
+	NodeIDShift = SequenceBits + ComponentIDBits
+	// TimestampShift is the left shift applied to the timestamp under DefaultLayout.
+	TimestampShift = SequenceBits + ComponentIDBits + NodeIDBits
 )
 
 // Flaki is the unique ID generator.
@@ -43,8 +50,49 @@ type Flaki struct {
 
 	// timeGen is the function that returns the current time.
 	timeGen func() time.Time
+
+	// defaultEncoding is the encoding used by NextIDEncodedString.
+	defaultEncoding Encoding
+
+	// checkNodeID, if set, is called once all options have been applied to veto the
+	// resolved node ID, e.g. after consulting an external registry for collisions.
+	checkNodeID CheckNodeID
+
+	// layout describes how the bits of a generated ID are split between the timestamp,
+	// the node ID, the component ID, and the sequence number.
+	layout Layout
+
+	// logicalTimestamp is the last timestamp (in real unix millis) used by NextIDAt. Unlike
+	// lastTimestamp, it tracks a caller-supplied, possibly historical, point in time rather
+	// than the wall clock; see NextIDAt.
+	logicalTimestamp int64
+
+	// monotonicAnchor and monotonicAnchorWallMillis are set by WithMonotonicClock. When
+	// monotonicAnchor is non-zero, the current time is derived from it instead of from
+	// timeGen, so that a wall clock stepped backward by NTP cannot make the generator
+	// refuse to produce IDs. See currentTimeInUnixMillis.
+	monotonicAnchor           time.Time
+	monotonicAnchorWallMillis int64
+
+	// monotonicElapsed returns how much time has passed since the given anchor. It defaults
+	// to time.Since, i.e. Go's monotonic clock reading, but is swappable via
+	// setMonotonicElapsed so tests can simulate elapsed time passing without the real OS
+	// clock's monotonic reading, which time.Since would otherwise tie it to.
+	monotonicElapsed func(anchor time.Time) time.Duration
 }
 
+// Encoding identifies one of the string encodings an ID can be rendered as.
+type Encoding int
+
+// The string encodings supported by NextIDEncodedString and the DefaultEncoding option.
+const (
+	Base10 Encoding = iota
+	Base2
+	Base32
+	Base58
+	Base64
+)
+
 // Option type is use to configure the Flaki generator. It takes one argument: the Flaki we are operating on.
 type Option func(*Flaki) error
 
@@ -59,13 +107,17 @@ type Option func(*Flaki) error
 func New(options ...Option) (*Flaki, error) {
 
 	var flaki = &Flaki{
-		componentID:   0,
-		nodeIDNodeID:  0,
-		startEpoch:    time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
-		lastTimestamp: -1,
-		sequence:      0,
-		timeGen:       time.Now,
-		mutex:         &sync.Mutex{},
+		componentID:      0,
+		nodeIDNodeID:     0,
+		startEpoch:       time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+		lastTimestamp:    -1,
+		logicalTimestamp: -1,
+		sequence:         0,
+		timeGen:          time.Now,
+		defaultEncoding:  Base10,
+		layout:           DefaultLayout,
+		mutex:            &sync.Mutex{},
+		monotonicElapsed: time.Since,
 	}
 
 	// Apply options to the Generator.
@@ -76,6 +128,16 @@ func New(options ...Option) (*Flaki, error) {
 		}
 	}
 
+	if flaki.componentID > flaki.layout.maxComponentID() {
+		return nil, fmt.Errorf("the component id must be in [%d..%d]", 0, flaki.layout.maxComponentID())
+	}
+	if flaki.nodeIDNodeID > flaki.layout.maxNodeID() {
+		return nil, fmt.Errorf("the node id must be in [%d..%d]", 0, flaki.layout.maxNodeID())
+	}
+	if flaki.checkNodeID != nil && !flaki.checkNodeID(flaki.nodeIDNodeID) {
+		return nil, fmt.Errorf("node id %d rejected by CheckNodeID", flaki.nodeIDNodeID)
+	}
+
 	return flaki, nil
 }
 
@@ -91,11 +153,11 @@ func (f *Flaki) NextID() (uint64, error) {
 		return 0, fmt.Errorf("clock moved backwards. Refusing to generate IDs for %d [ms]", prevTimestamp-timestamp)
 	}
 
-	// If too many IDs (more than 2^sequenceBits) are requested in a given time unit (millisecond),
+	// If too many IDs (more than 2^SequenceBits) are requested in a given time unit (millisecond),
 	// the sequence overflows. If it happens, we wait till the next time unit to generate new IDs,
 	// otherwise we end up with duplicates.
 	if timestamp == prevTimestamp {
-		f.sequence = (f.sequence + 1) & sequenceMask
+		f.sequence = (f.sequence + 1) & f.layout.sequenceMask()
 		if f.sequence == 0 {
 			timestamp = f.tilNextMillis(prevTimestamp)
 		}
@@ -104,19 +166,101 @@ func (f *Flaki) NextID() (uint64, error) {
 	}
 
 	f.lastTimestamp = timestamp
-	var id = (uint64(timestamp-timeToUnixMillis(f.startEpoch)) << timestampLeftShift) |
-		(f.nodeIDNodeID << nodeIDNodeIDShift) | (f.componentID << componentIDShift) | f.sequence
+	var id = (uint64(timestamp-timeToUnixMillis(f.startEpoch)) << f.layout.timestampShift()) |
+		(f.nodeIDNodeID << f.layout.nodeIDShift()) | (f.componentID << f.layout.componentIDShift()) | f.sequence
 
 	return id, nil
 }
 
-// NextIDString returns the NextID as a string.
+// Components holds the individual fields that make up a Flaki ID, as returned by Decompose.
+type Components struct {
+	Timestamp   time.Time
+	NodeID      uint64
+	ComponentID uint64
+	Sequence    uint64
+}
+
+// Decompose breaks a previously generated ID back into its components. The ID's timestamp
+// and fields are reconstructed using the generator's startEpoch and layout, so the same
+// Flaki (or one configured with the same StartEpoch and Layout) that produced the ID must
+// be used to decompose it.
+func (f *Flaki) Decompose(id uint64) Components {
+	var elapsedMillis = int64(id >> f.layout.timestampShift())
+	var timestampMillis = elapsedMillis + timeToUnixMillis(f.startEpoch)
+
+	return Components{
+		Timestamp:   time.Unix(0, timestampMillis*int64(time.Millisecond)).UTC(),
+		NodeID:      (id >> f.layout.nodeIDShift()) & f.layout.maxNodeID(),
+		ComponentID: (id >> f.layout.componentIDShift()) & f.layout.maxComponentID(),
+		Sequence:    id & f.layout.sequenceMask(),
+	}
+}
+
+// NextIDString returns the NextID as a base10 (decimal) string.
 func (f *Flaki) NextIDString() (string, error) {
 	var id, err = f.NextID()
 	if err != nil {
 		return "", err
 	}
-	return strconv.FormatUint(id, 10), nil
+	return ID(id).String(), nil
+}
+
+// NextIDBase2 returns the NextID as a base2 (binary) string.
+func (f *Flaki) NextIDBase2() (string, error) {
+	var id, err = f.NextID()
+	if err != nil {
+		return "", err
+	}
+	return ID(id).Base2(), nil
+}
+
+// NextIDBase32 returns the NextID as a base32 string.
+func (f *Flaki) NextIDBase32() (string, error) {
+	var id, err = f.NextID()
+	if err != nil {
+		return "", err
+	}
+	return ID(id).Base32(), nil
+}
+
+// NextIDBase58 returns the NextID as a base58 string.
+func (f *Flaki) NextIDBase58() (string, error) {
+	var id, err = f.NextID()
+	if err != nil {
+		return "", err
+	}
+	return ID(id).Base58(), nil
+}
+
+// NextIDBase64 returns the NextID as a URL-safe base64 string.
+func (f *Flaki) NextIDBase64() (string, error) {
+	var id, err = f.NextID()
+	if err != nil {
+		return "", err
+	}
+	return ID(id).Base64(), nil
+}
+
+// NextIDEncodedString returns the NextID as a string, encoded using the generator's
+// default encoding (set with the DefaultEncoding option, base10 unless specified).
+func (f *Flaki) NextIDEncodedString() (string, error) {
+	var id, err = f.NextID()
+	if err != nil {
+		return "", err
+	}
+
+	switch f.defaultEncoding {
+	case Base2:
+		return ID(id).Base2(), nil
+	case Base32:
+		return ID(id).Base32(), nil
+	case Base58:
+		return ID(id).Base58(), nil
+	case Base64:
+		return ID(id).Base64(), nil
+	default:
+		return ID(id).String(), nil
+	}
 }
 
 // NextValidID always returns a new unique ID, it never returns an error.
@@ -150,17 +294,43 @@ func (f *Flaki) tilNextMillis(prevTimestamp int64) int64 {
 	return timestamp
 }
 
-// epochValidity returns the date till which Flaki can generate valid IDs.
-func epochValidity(startEpoch time.Time) time.Time {
-	var durationMilliseconds int64 = (1 << timestampBits) - 1
-	var durationNanoseconds = durationMilliseconds * 1e6
+// EpochValidity returns the date until which this generator can produce unique IDs before
+// its timestamp field overflows.
+func (f *Flaki) EpochValidity() time.Time {
+	return epochValidity(f.startEpoch, f.layout)
+}
+
+// ClockStatus reports whether the generator's clock (the wall clock, or the monotonic
+// reading under WithMonotonicClock) is currently behaving, i.e. not behind the last
+// timestamp used to generate an ID. Unlike NextID, it does not consume a sequence
+// number, so it is safe to call from a health check on every request.
+func (f *Flaki) ClockStatus() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
 
-	var validityDuration = time.Duration(durationNanoseconds)
-	var validUntil = startEpoch.Add(validityDuration)
-	return validUntil
+	var timestamp = f.currentTimeInUnixMillis()
+	if timestamp < f.lastTimestamp {
+		return fmt.Errorf("clock moved backwards. Refusing to generate IDs for %d [ms]", f.lastTimestamp-timestamp)
+	}
+	return nil
+}
+
+// epochValidity returns the date till which Flaki can generate valid IDs under the given layout.
+func epochValidity(startEpoch time.Time, layout Layout) time.Time {
+	// Split into seconds and a millisecond remainder rather than going through time.Duration,
+	// which overflows for timestamp fields of 44 bits or more.
+	var durationMilliseconds = uint64(1)<<layout.TimestampBits - 1
+	var seconds = int64(durationMilliseconds / 1000)
+	var nanoseconds = int64(durationMilliseconds%1000) * int64(time.Millisecond)
+
+	var validUntil = time.Unix(startEpoch.Unix()+seconds, int64(startEpoch.Nanosecond())+nanoseconds)
+	return validUntil.In(startEpoch.Location())
 }
 
 func (f *Flaki) currentTimeInUnixMillis() int64 {
+	if !f.monotonicAnchor.IsZero() {
+		return f.monotonicAnchorWallMillis + f.monotonicElapsed(f.monotonicAnchor).Milliseconds()
+	}
 	return timeToUnixMillis(f.timeGen())
 }
 
@@ -168,23 +338,21 @@ func timeToUnixMillis(t time.Time) int64 {
 	return t.UnixNano() / 1e6
 }
 
-// ComponentID is the option used to set the component ID.
+// ComponentID is the option used to set the component ID. It is validated against the
+// generator's layout (DefaultLayout unless WithLayout is also given) once all options have
+// been applied, so ComponentID and WithLayout may be passed to New in either order.
 func ComponentID(id uint64) Option {
 	return func(f *Flaki) error {
-		if id > maxComponentID {
-			return fmt.Errorf("the component id must be in [%d..%d]", 0, maxComponentID)
-		}
 		f.componentID = id
 		return nil
 	}
 }
 
-// NodeID is the option used to set the node ID.
+// NodeID is the option used to set the node ID. It is validated against the generator's
+// layout (DefaultLayout unless WithLayout is also given) once all options have been
+// applied, so NodeID and WithLayout may be passed to New in either order.
 func NodeID(id uint64) Option {
 	return func(f *Flaki) error {
-		if id > maxNodeID {
-			return fmt.Errorf("the node id must be in [%d..%d]", 0, maxNodeID)
-		}
 		f.nodeIDNodeID = id
 		return nil
 	}
@@ -205,8 +373,44 @@ func StartEpoch(epoch time.Time) Option {
 	}
 }
 
+// DefaultEncoding is the option used to set the string encoding returned by NextIDEncodedString.
+func DefaultEncoding(encoding Encoding) Option {
+	return func(f *Flaki) error {
+		if encoding < Base10 || encoding > Base64 {
+			return fmt.Errorf("unknown encoding %d", encoding)
+		}
+		f.defaultEncoding = encoding
+		return nil
+	}
+}
+
+// WithMonotonicClock is the option that makes the generator derive the current time from
+// Go's monotonic clock reading instead of repeatedly calling timeGen. It anchors a base
+// time, read once from timeGen when the option runs, then computes every later timestamp as
+// that anchor's wall-clock millis plus the elapsed duration since (monotonicElapsed, which
+// defaults to time.Since, i.e. Go's monotonic clock reading). This makes NextID immune to
+// the wall clock being stepped backward by NTP after the anchor is taken, a well-documented
+// failure mode for snowflake-style generators, at the cost of timestamps drifting from the
+// system clock if it is later corrected forward. See setMonotonicElapsed for how tests
+// exercise this without waiting on the real OS clock.
+func WithMonotonicClock() Option {
+	return func(f *Flaki) error {
+		f.monotonicAnchor = f.timeGen()
+		f.monotonicAnchorWallMillis = timeToUnixMillis(f.monotonicAnchor)
+		return nil
+	}
+}
+
 // setTimeGen set the function that returns the current time. It is used in the tests
 // to control the time.
 func (f *Flaki) setTimeGen(timeGen func() time.Time) {
 	f.timeGen = timeGen
 }
+
+// setMonotonicElapsed sets the function WithMonotonicClock uses to compute elapsed time
+// since its anchor. It is used in the tests to simulate elapsed time passing, including an
+// NTP step-back's effect on the real monotonic clock, without depending on the real OS
+// clock's monotonic reading the way time.Since does.
+func (f *Flaki) setMonotonicElapsed(monotonicElapsed func(anchor time.Time) time.Duration) {
+	f.monotonicElapsed = monotonicElapsed
+}
@@ -0,0 +1,72 @@
+package flaki
+
+import (
+	"fmt"
+	"time"
+)
+
+// NextIDAt returns a new unique ID whose embedded timestamp is t instead of time.Now(), for
+// backfilling records that must sort by their original creation time rather than the time
+// the ID happened to be generated. t must not be in the future: NextIDAt shares lastTimestamp
+// and the sequence counter with NextID on the same *Flaki, so a future-dated t (e.g. from a
+// timezone bug in the caller) would otherwise push lastTimestamp ahead of the real clock and
+// make every subsequent real-time NextID call on that instance fail with "clock moved
+// backwards" until the real clock catches up.
+//
+// Repeated calls with the same millisecond are kept unique the same way NextID keeps
+// concurrent calls within the same millisecond unique: by incrementing the sequence number.
+// If the sequence overflows, NextIDAt advances its own logical clock by one millisecond
+// instead of sleeping on the real clock, so a large backfill completes quickly. That logical
+// clock is a high-water mark, taken as the later of the last NextIDAt timestamp and the last
+// NextID timestamp: a later call for that millisecond or any earlier one is clamped forward
+// to it rather than being allowed to reuse it, or it would regenerate an ID already handed
+// out. The embedded timestamp of the returned ID is therefore later than t whenever t is
+// earlier than that mark: when rows sharing a millisecond overflow the sequence, when rows
+// are backfilled out of chronological order, and on an instance that has already generated
+// IDs with NextID, where it is the millisecond of the last NextID call rather than t.
+// The logical clock only ever pushes the generator's lastTimestamp forward, never backward,
+// so real-time NextID calls interleaved with or following a backfill still behave correctly.
+func (f *Flaki) NextIDAt(t time.Time) (uint64, error) {
+	if t.Before(f.startEpoch) {
+		return 0, fmt.Errorf("time %s is before the start epoch %s", t, f.startEpoch)
+	}
+	if t.After(epochValidity(f.startEpoch, f.layout)) {
+		return 0, fmt.Errorf("time %s is past the epoch validity limit", t)
+	}
+	if now := f.currentTimeInUnixMillis(); timeToUnixMillis(t) > now {
+		return 0, fmt.Errorf("time %s is in the future (current time is %s); NextIDAt is for backfilling past records, not scheduling future ones",
+			t, time.Unix(0, now*int64(time.Millisecond)).UTC())
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	var prevTimestamp = f.logicalTimestamp
+	if f.lastTimestamp > prevTimestamp {
+		prevTimestamp = f.lastTimestamp
+	}
+
+	var timestamp = timeToUnixMillis(t)
+	if timestamp < prevTimestamp {
+		timestamp = prevTimestamp
+	}
+
+	if timestamp == prevTimestamp {
+		f.sequence = (f.sequence + 1) & f.layout.sequenceMask()
+		if f.sequence == 0 {
+			timestamp = prevTimestamp + 1
+		}
+	} else {
+		f.sequence = 0
+	}
+
+	f.logicalTimestamp = timestamp
+	if timestamp > f.lastTimestamp {
+		f.lastTimestamp = timestamp
+	}
+
+	var id = (uint64(timestamp-timeToUnixMillis(f.startEpoch)) << f.layout.timestampShift()) |
+		(f.nodeIDNodeID << f.layout.nodeIDShift()) | (f.componentID << f.layout.componentIDShift()) | f.sequence
+
+	return id, nil
+}
@@ -0,0 +1,121 @@
+package flaki
+
+import (
+	"database/sql/driver"
+	"encoding/base32"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+)
+
+// ID is a Flaki-generated unique ID. It is a uint64 under the hood, but carries its own
+// String/Base2/Base32/Base58/Base64 encoders and JSON/SQL marshalers so that IDs embed
+// cleanly in REST APIs, URLs, and databases without callers having to convert by hand.
+type ID uint64
+
+// base58Alphabet is the Bitcoin base58 alphabet: all of [0-9A-Za-z] except 0, O, I and l,
+// which are visually ambiguous.
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// String returns the base10 (decimal) representation of the ID. This is the historical
+// encoding returned by NextIDString.
+func (id ID) String() string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// Base2 returns the base2 (binary) representation of the ID.
+func (id ID) Base2() string {
+	return strconv.FormatUint(uint64(id), 2)
+}
+
+// Base32 returns the base32 representation of the ID, using the standard base32 alphabet
+// without padding.
+func (id ID) Base32() string {
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(id.Bytes())
+}
+
+// Base58 returns the base58 representation of the ID, using the Bitcoin alphabet. Base58
+// avoids characters that are easily confused with one another and is safe to use in URLs.
+func (id ID) Base58() string {
+	if id == 0 {
+		return string(base58Alphabet[0])
+	}
+
+	var n = uint64(id)
+	var buf [16]byte
+	var i = len(buf)
+	for n > 0 {
+		i--
+		buf[i] = base58Alphabet[n%58]
+		n /= 58
+	}
+	return string(buf[i:])
+}
+
+// Base64 returns the URL-safe, unpadded base64 representation of the ID.
+func (id ID) Base64() string {
+	return base64.RawURLEncoding.EncodeToString(id.Bytes())
+}
+
+// Bytes returns the big-endian 8-byte representation of the ID.
+func (id ID) Bytes() []byte {
+	return []byte{
+		byte(id >> 56), byte(id >> 48), byte(id >> 40), byte(id >> 32),
+		byte(id >> 24), byte(id >> 16), byte(id >> 8), byte(id),
+	}
+}
+
+// MarshalJSON encodes the ID as a JSON string (rather than a JSON number), so that the
+// value survives round-trips through JSON decoders that parse numbers as float64 and would
+// otherwise lose precision above 2^53.
+func (id ID) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + id.String() + `"`), nil
+}
+
+// UnmarshalJSON decodes an ID from either a JSON string or a JSON number.
+func (id *ID) UnmarshalJSON(data []byte) error {
+	var s = string(data)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+
+	var n, err = strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid flaki ID %q: %s", data, err)
+	}
+	*id = ID(n)
+	return nil
+}
+
+// Value implements driver.Valuer so that an ID can be written directly as a column value.
+func (id ID) Value() (driver.Value, error) {
+	return int64(id), nil
+}
+
+// Scan implements sql.Scanner so that an ID can be read directly out of a database column.
+func (id *ID) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case int64:
+		*id = ID(v)
+		return nil
+	case uint64:
+		*id = ID(v)
+		return nil
+	case []byte:
+		var n, err = strconv.ParseUint(string(v), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid flaki ID %q: %s", v, err)
+		}
+		*id = ID(n)
+		return nil
+	case string:
+		var n, err = strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid flaki ID %q: %s", v, err)
+		}
+		*id = ID(n)
+		return nil
+	default:
+		return fmt.Errorf("unsupported type for flaki ID: %T", src)
+	}
+}
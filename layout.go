@@ -0,0 +1,89 @@
+package flaki
+
+import "fmt"
+
+// Layout describes how the bits of a Flaki ID are split between the timestamp, the node
+// ID, the component ID, and the per-millisecond sequence number. The four fields must sum
+// to 64, the width of the generated ID.
+type Layout struct {
+	TimestampBits   uint
+	NodeIDBits      uint
+	ComponentIDBits uint
+	SequenceBits    uint
+}
+
+// DefaultLayout is the layout Flaki has always used: 42-bit timestamp, 2-bit node ID,
+// 5-bit component ID, and 15-bit sequence number. New uses it unless WithLayout is given.
+var DefaultLayout = Layout{
+	TimestampBits:   TimestampBits,
+	NodeIDBits:      NodeIDBits,
+	ComponentIDBits: ComponentIDBits,
+	SequenceBits:    SequenceBits,
+}
+
+// PresetSnowflake mirrors Twitter Snowflake's bit layout: 42-bit timestamp, 10-bit node ID,
+// no component ID, and 12-bit sequence number.
+var PresetSnowflake = Layout{
+	TimestampBits:   42,
+	NodeIDBits:      10,
+	ComponentIDBits: 0,
+	SequenceBits:    12,
+}
+
+// PresetSonyflake mirrors Sonyflake's bit layout: 40-bit timestamp, 16-bit machine (node)
+// ID, no component ID, and 8-bit sequence number. Unlike Sonyflake, Flaki's timestamp is
+// still expressed in milliseconds, not 10-millisecond units, so the time range it covers
+// differs from the original.
+var PresetSonyflake = Layout{
+	TimestampBits:   40,
+	NodeIDBits:      16,
+	ComponentIDBits: 0,
+	SequenceBits:    8,
+}
+
+// validate checks that the layout's fields sum to 64, the width of a Flaki ID.
+func (l Layout) validate() error {
+	var sum = l.TimestampBits + l.NodeIDBits + l.ComponentIDBits + l.SequenceBits
+	if sum != 64 {
+		return fmt.Errorf("layout bits must sum to 64, got %d (timestamp=%d node=%d component=%d sequence=%d)",
+			sum, l.TimestampBits, l.NodeIDBits, l.ComponentIDBits, l.SequenceBits)
+	}
+	return nil
+}
+
+func (l Layout) maxNodeID() uint64 {
+	return (1 << l.NodeIDBits) - 1
+}
+
+func (l Layout) maxComponentID() uint64 {
+	return (1 << l.ComponentIDBits) - 1
+}
+
+func (l Layout) sequenceMask() uint64 {
+	return (1 << l.SequenceBits) - 1
+}
+
+func (l Layout) componentIDShift() uint {
+	return l.SequenceBits
+}
+
+func (l Layout) nodeIDShift() uint {
+	return l.SequenceBits + l.ComponentIDBits
+}
+
+func (l Layout) timestampShift() uint {
+	return l.SequenceBits + l.ComponentIDBits + l.NodeIDBits
+}
+
+// WithLayout is the option used to override the default bit layout, e.g. with
+// PresetSnowflake or PresetSonyflake, or a custom Layout for deployments that need more
+// node or component IDs than the default layout's 2 and 5 bits allow.
+func WithLayout(layout Layout) Option {
+	return func(f *Flaki) error {
+		if err := layout.validate(); err != nil {
+			return err
+		}
+		f.layout = layout
+		return nil
+	}
+}
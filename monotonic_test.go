@@ -0,0 +1,120 @@
+package flaki
+
+import (
+	"testing"
+	"time"
+)
+
+// withTimeGen is a test-only Option (setTimeGen itself is unexported and has no public
+// Option wrapper) used to inject a controllable clock into New, the same way setTimeGen is
+// documented to be used by tests.
+func withTimeGen(timeGen func() time.Time) Option {
+	return func(f *Flaki) error {
+		f.setTimeGen(timeGen)
+		return nil
+	}
+}
+
+// withMonotonicElapsed is a test-only Option used to replace WithMonotonicClock's elapsed-time
+// source (normally time.Since, i.e. Go's monotonic clock reading) with a fake one. This is what
+// makes the feature testable at all: the real monotonic clock is tied to the real OS clock, so
+// no fake timeGen can ever make it appear to step backward in a test.
+func withMonotonicElapsed(monotonicElapsed func(anchor time.Time) time.Duration) Option {
+	return func(f *Flaki) error {
+		f.setMonotonicElapsed(monotonicElapsed)
+		return nil
+	}
+}
+
+// TestWithMonotonicClockTracksElapsedTime checks that a WithMonotonicClock generator computes
+// the current time as its anchor plus monotonicElapsed(anchor), rather than just happening to
+// not return an error. Without this, a test could pass identically even if the anchoring
+// arithmetic were entirely broken, since "no error" is easy to satisfy by accident.
+func TestWithMonotonicClockTracksElapsedTime(t *testing.T) {
+	var anchor = time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	var timeGen = func() time.Time { return anchor }
+
+	var elapsed time.Duration
+	var monotonicElapsed = func(a time.Time) time.Duration { return elapsed }
+
+	var f, err = New(withTimeGen(timeGen), WithMonotonicClock(), withMonotonicElapsed(monotonicElapsed))
+	if err != nil {
+		t.Fatalf("New() returned an error: %s", err)
+	}
+
+	elapsed = 90 * time.Second
+	var id, err2 = f.NextID()
+	if err2 != nil {
+		t.Fatalf("NextID() returned an error: %s", err2)
+	}
+
+	var want = anchor.Add(elapsed)
+	var got = f.Decompose(id).Timestamp
+	if !got.Equal(want) {
+		t.Fatalf("expected the ID's timestamp to be the anchor (%s) plus monotonicElapsed (%s) = %s, got %s",
+			anchor, elapsed, want, got)
+	}
+}
+
+// TestWithMonotonicClockSurvivesStepBack simulates an NTP step-back of the wall clock
+// (timeGen) while monotonicElapsed, standing in for the real monotonic clock, keeps advancing
+// independently of it. It checks that a generator configured with WithMonotonicClock keeps
+// producing IDs through the step, while a plain generator given the same wall clock correctly
+// refuses to — the same distinction a real NTP correction and the real monotonic clock would
+// produce.
+func TestWithMonotonicClockSurvivesStepBack(t *testing.T) {
+	var wallClock = time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	var timeGen = func() time.Time { return wallClock }
+
+	var elapsed time.Duration
+	var monotonicElapsed = func(a time.Time) time.Duration { return elapsed }
+
+	// WithMonotonicClock must come after withTimeGen, since it anchors on whatever timeGen
+	// is current when the option runs.
+	var monotonic, err = New(withTimeGen(timeGen), WithMonotonicClock(), withMonotonicElapsed(monotonicElapsed))
+	if err != nil {
+		t.Fatalf("New() returned an error: %s", err)
+	}
+
+	var plain, err2 = New(withTimeGen(timeGen))
+	if err2 != nil {
+		t.Fatalf("New() returned an error: %s", err2)
+	}
+
+	if _, err := monotonic.NextID(); err != nil {
+		t.Fatalf("NextID() returned an error: %s", err)
+	}
+	if _, err := plain.NextID(); err != nil {
+		t.Fatalf("NextID() returned an error: %s", err)
+	}
+
+	// Step the wall clock backward, as an NTP correction would, while the elapsed time since
+	// construction only ever grows, as the real monotonic clock's would.
+	wallClock = wallClock.Add(-time.Minute)
+	elapsed += time.Millisecond
+
+	if _, err := plain.NextID(); err == nil {
+		t.Fatal("expected the plain generator to refuse to generate an ID after the clock stepped backward")
+	}
+	if _, err := monotonic.NextID(); err != nil {
+		t.Fatalf("WithMonotonicClock generator should be immune to the step backward, got: %s", err)
+	}
+}
+
+// TestWithMonotonicClockAnchorsOnTimeGen checks that WithMonotonicClock takes its anchor
+// from timeGen rather than always reading the real time.Now(), so that a caller-supplied
+// clock source is actually honored at construction time.
+func TestWithMonotonicClockAnchorsOnTimeGen(t *testing.T) {
+	const offset = 10 * time.Minute
+	var timeGen = func() time.Time { return time.Now().Add(-offset) }
+
+	var f, err = New(withTimeGen(timeGen), WithMonotonicClock())
+	if err != nil {
+		t.Fatalf("New() returned an error: %s", err)
+	}
+
+	var want = timeGen()
+	if diff := f.monotonicAnchor.Sub(want); diff > time.Second || diff < -time.Second {
+		t.Fatalf("expected the anchor to be taken from timeGen (around %s), got %s", want, f.monotonicAnchor)
+	}
+}
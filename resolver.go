@@ -0,0 +1,133 @@
+package flaki
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// NodeIDResolver returns the node ID to use for a Flaki generator. It is called once, at
+// New() time, so that the node ID can be derived from the environment (private IP,
+// hostname, ...) instead of being hand-assigned.
+type NodeIDResolver func() (uint64, error)
+
+// ComponentIDResolver returns the component ID to use for a Flaki generator. See NodeIDResolver.
+type ComponentIDResolver func() (uint64, error)
+
+// CheckNodeID is a hook called with the resolved (or explicitly configured) node ID after
+// all options have been applied. It lets callers veto the node ID, for instance after
+// consulting an external registry (etcd, Redis, ...) to detect a collision with another
+// instance already using that ID.
+type CheckNodeID func(nodeID uint64) bool
+
+// WithNodeIDResolver is the option used to derive the node ID from a NodeIDResolver instead
+// of a hard-coded value. The node field is only as wide as the generator's layout allows, so
+// the resolved value is folded deterministically into [0..maxNodeID] via modulo. Pass
+// WithLayout before WithNodeIDResolver if both are given, so the fold uses the right width.
+func WithNodeIDResolver(resolver NodeIDResolver) Option {
+	return func(f *Flaki) error {
+		var id, err = resolver()
+		if err != nil {
+			return fmt.Errorf("could not resolve node id: %s", err)
+		}
+		return NodeID(id % (f.layout.maxNodeID() + 1))(f)
+	}
+}
+
+// WithComponentIDResolver is the option used to derive the component ID from a
+// ComponentIDResolver instead of a hard-coded value. See WithNodeIDResolver for the folding
+// and option-ordering caveats.
+func WithComponentIDResolver(resolver ComponentIDResolver) Option {
+	return func(f *Flaki) error {
+		var id, err = resolver()
+		if err != nil {
+			return fmt.Errorf("could not resolve component id: %s", err)
+		}
+		return ComponentID(id % (f.layout.maxComponentID() + 1))(f)
+	}
+}
+
+// WithCheckNodeID is the option used to veto the node ID once all other options have been
+// applied. It is evaluated last, regardless of the order in which options were passed to New.
+func WithCheckNodeID(check CheckNodeID) Option {
+	return func(f *Flaki) error {
+		f.checkNodeID = check
+		return nil
+	}
+}
+
+// NodeIDFromPrivateIPv4 returns a NodeIDResolver that derives the node ID from the lower
+// bits of the host's private IPv4 address, the same technique Sonyflake uses for its
+// default machine ID.
+func NodeIDFromPrivateIPv4() NodeIDResolver {
+	return func() (uint64, error) {
+		var ip, err = privateIPv4()
+		if err != nil {
+			return 0, err
+		}
+		return uint64(ip[2])<<8 | uint64(ip[3]), nil
+	}
+}
+
+// NodeIDFromHostnameHash returns a NodeIDResolver that derives the node ID from a SHA-1
+// hash of the machine's hostname, folded down to a uint64.
+func NodeIDFromHostnameHash() NodeIDResolver {
+	return func() (uint64, error) {
+		var hostname, err = os.Hostname()
+		if err != nil {
+			return 0, fmt.Errorf("could not get hostname: %s", err)
+		}
+
+		var sum = sha1.Sum([]byte(hostname))
+		return binary.BigEndian.Uint64(sum[:8]), nil
+	}
+}
+
+// NodeIDFromEnv returns a NodeIDResolver that reads the node ID from the given environment
+// variable, e.g. NodeIDFromEnv("FLAKI_NODE_ID").
+func NodeIDFromEnv(key string) NodeIDResolver {
+	return func() (uint64, error) {
+		var value = os.Getenv(key)
+		if value == "" {
+			return 0, fmt.Errorf("environment variable %s is not set", key)
+		}
+
+		var id, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("environment variable %s is not a valid node id: %s", key, err)
+		}
+		return id, nil
+	}
+}
+
+// privateIPv4 returns the first private IPv4 address found on the host's network interfaces.
+func privateIPv4() (net.IP, error) {
+	var addrs, err = net.InterfaceAddrs()
+	if err != nil {
+		return nil, fmt.Errorf("could not list network interfaces: %s", err)
+	}
+
+	for _, addr := range addrs {
+		var ipNet, ok = addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() {
+			continue
+		}
+
+		var ip = ipNet.IP.To4()
+		if ip != nil && isPrivateIPv4(ip) {
+			return ip, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no private ipv4 address found")
+}
+
+// isPrivateIPv4 reports whether ip is within one of the RFC 1918 private address ranges.
+func isPrivateIPv4(ip net.IP) bool {
+	return ip[0] == 10 ||
+		(ip[0] == 172 && ip[1] >= 16 && ip[1] <= 31) ||
+		(ip[0] == 192 && ip[1] == 168)
+}
@@ -0,0 +1,12 @@
+// Package service wraps a *flaki.Flaki generator as an HTTP service, so that multiple
+// processes can share a single authoritative (node ID, component ID) pair instead of each
+// running its own generator. It exposes /nextid, /nextid/string, /nextid/batch, and
+// /healthz.
+//
+// braoru/flaki#chunk0-6 asked for this package to also expose a gRPC streaming
+// NextIDs(batch_size) endpoint alongside the HTTP one. That half is NOT delivered: it needs
+// a committed, generated client/server stub, and protoc isn't available here to produce one
+// and keep it in sync with the .proto, so no hand-written stand-in was committed either.
+// /nextid/batch covers the same batching/prefetch use case over plain HTTP in the meantime,
+// but the gRPC half of chunk0-6 is still open.
+package service
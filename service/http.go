@@ -0,0 +1,122 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/braoru/flaki"
+)
+
+// maxBatchSize bounds the n query parameter accepted by /nextid/batch, so that a careless
+// or malicious caller can't force the server to generate millions of IDs in one request.
+const maxBatchSize = 10000
+
+// HTTPServer exposes a *flaki.Flaki generator over plain HTTP. It implements http.Handler,
+// so it can be mounted directly or wrapped with the caller's own middleware.
+type HTTPServer struct {
+	flaki *flaki.Flaki
+	mux   *http.ServeMux
+}
+
+// NewHTTPServer returns an HTTPServer backed by the given generator, serving:
+//
+//	GET /nextid        - the next ID, as a decimal number (text/plain)
+//	GET /nextid/string - the next ID, as a base10 string (text/plain), identical payload to
+//	                     /nextid but kept distinct so callers can rely on the latter never
+//	                     being reinterpreted as a JSON number
+//	GET /nextid/batch?n=100 - n freshly generated IDs, as a JSON object whose "ids" field is an array of numbers; see
+//	                          BatchResponse. Lets batch-oriented clients (flaki/client)
+//	                          amortize the cost of the round-trip over several IDs.
+//	GET /healthz       - JSON health status, see Health
+func NewHTTPServer(f *flaki.Flaki) *HTTPServer {
+	var s = &HTTPServer{flaki: f, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/nextid", s.handleNextID)
+	s.mux.HandleFunc("/nextid/string", s.handleNextIDString)
+	s.mux.HandleFunc("/nextid/batch", s.handleNextIDBatch)
+	s.mux.HandleFunc("/healthz", s.handleHealthz)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *HTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *HTTPServer) handleNextID(w http.ResponseWriter, r *http.Request) {
+	var id, err = s.flaki.NextID()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(flaki.ID(id).String()))
+}
+
+func (s *HTTPServer) handleNextIDString(w http.ResponseWriter, r *http.Request) {
+	var id, err = s.flaki.NextIDString()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(id))
+}
+
+// BatchResponse is the JSON payload returned by /nextid/batch.
+type BatchResponse struct {
+	IDs []uint64 `json:"ids"`
+}
+
+func (s *HTTPServer) handleNextIDBatch(w http.ResponseWriter, r *http.Request) {
+	var n = 1
+	if raw := r.URL.Query().Get("n"); raw != "" {
+		var parsed, err = strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxBatchSize {
+			http.Error(w, "n must be an integer in [1.."+strconv.Itoa(maxBatchSize)+"]", http.StatusBadRequest)
+			return
+		}
+		n = parsed
+	}
+
+	var ids = make([]uint64, 0, n)
+	for i := 0; i < n; i++ {
+		var id, err = s.flaki.NextID()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(BatchResponse{IDs: ids})
+}
+
+// Health reports whether the generator's clock is currently healthy and how much longer its
+// epoch remains valid.
+type Health struct {
+	OK            bool      `json:"ok"`
+	EpochValidity time.Time `json:"epoch_validity"`
+	Error         string    `json:"error,omitempty"`
+}
+
+func (s *HTTPServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	var health = Health{EpochValidity: s.flaki.EpochValidity()}
+
+	if err := s.flaki.ClockStatus(); err != nil {
+		health.OK = false
+		health.Error = err.Error()
+	} else {
+		health.OK = true
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if !health.OK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(health)
+}